@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"fmt"
+	stdhttp "net/http"
 	_ "net/http/pprof"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/logrusorgru/aurora"
 	"github.com/projectdiscovery/gologger"
@@ -21,6 +24,9 @@ import (
 	"github.com/projectdiscovery/nuclei/v3/pkg/catalog/loader"
 	"github.com/projectdiscovery/nuclei/v3/pkg/core"
 	fuzzStats "github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats"
+	_ "github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats/driver/clickhouse"
+	_ "github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats/driver/postgres"
+	_ "github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats/driver/sqlite"
 	"github.com/projectdiscovery/nuclei/v3/pkg/input"
 	"github.com/projectdiscovery/nuclei/v3/pkg/loader/parser"
 	parsers "github.com/projectdiscovery/nuclei/v3/pkg/loader/workflow"
@@ -42,8 +48,19 @@ type nucleiExecutor struct {
 	store        *loader.Store
 	options      *NucleiExecutorOptions
 	executorOpts protocols.ExecutorOptions
+
+	// deadlineMu guards deadline/cancel, which SetDeadline and ExecuteScan
+	// touch concurrently: an in-flight scan's context can be cancelled by a
+	// SetDeadline call racing with it.
+	deadlineMu sync.Mutex
+	deadline   time.Duration
+	cancel     context.CancelFunc
 }
 
+// ErrDeadlineExceeded is returned by ExecuteScan when the executor's
+// configured deadline has already elapsed before the scan could start.
+var ErrDeadlineExceeded = errors.New("nuclei: scan deadline exceeded")
+
 type NucleiExecutorOptions struct {
 	Options            *types.Options
 	Output             output.Writer
@@ -57,6 +74,20 @@ type NucleiExecutorOptions struct {
 	Colorizer          aurora.Aurora
 	Parser             parser.Parser
 	TemporaryDirectory string
+
+	// ScanDeadline bounds how long a single ExecuteScan call is allowed to
+	// run before its context is cancelled, so a stuck target can't tie up a
+	// --dast-server worker indefinitely. Zero means no deadline; callers
+	// wiring up a long-running server typically default this to something
+	// like 30 seconds. See nucleiExecutor.SetDeadline to change it at
+	// runtime.
+	ScanDeadline time.Duration
+
+	// FuzzStatsBatchSize and FuzzStatsFlushInterval tune the async batch
+	// writer of the stats backend selected by Options.DASTScanName. Zero
+	// values fall back to the driver's own defaults.
+	FuzzStatsBatchSize     int
+	FuzzStatsFlushInterval time.Duration
 }
 
 func newNucleiExecutor(opts *NucleiExecutorOptions) (*nucleiExecutor, error) {
@@ -85,11 +116,23 @@ func newNucleiExecutor(opts *NucleiExecutorOptions) (*nucleiExecutor, error) {
 		GlobalMatchers:      globalmatchers.New(),
 	}
 	if opts.Options.DASTScanName != "" {
-		var err error
-		executorOpts.FuzzStatsDB, err = fuzzStats.NewTracker(opts.Options.DASTScanName)
+		// DASTScanName now doubles as a stats backend DSN: a bare name keeps
+		// the historical single-file sqlite behavior, while a
+		// "postgres://..." or "clickhouse://..." DSN selects a shared,
+		// multi-node capable backend instead.
+		tracker, err := fuzzStats.NewTracker(opts.Options.DASTScanName,
+			fuzzStats.WithBatchSize(opts.FuzzStatsBatchSize),
+			fuzzStats.WithFlushInterval(opts.FuzzStatsFlushInterval),
+		)
 		if err != nil {
 			return nil, errors.Wrap(err, "could not create fuzz stats db")
 		}
+		executorOpts.FuzzStatsDB = tracker
+
+		// Exposed on the same mux as net/http/pprof above so long-running
+		// --dast-server sessions get live Prometheus stats without paying
+		// the cost of querying the stats backend on every scrape.
+		stdhttp.Handle("/metrics", fuzzStats.MetricsHandler(tracker))
 	}
 
 	if opts.Options.ShouldUseHostError() {
@@ -135,10 +178,58 @@ func newNucleiExecutor(opts *NucleiExecutorOptions) (*nucleiExecutor, error) {
 		store:        store,
 		options:      opts,
 		executorOpts: executorOpts,
+		deadline:     opts.ScanDeadline,
 	}, nil
 }
 
-func (n *nucleiExecutor) ExecuteScan(target PostReuestsHandlerRequest) error {
+// SetDeadline configures the per-request deadline applied to every
+// subsequent ExecuteScan call, mirroring the semantics of
+// net.Conn.SetDeadline: a zero d disables the deadline entirely, and a
+// negative d is treated as already elapsed, so the next ExecuteScan call
+// fails immediately with ErrDeadlineExceeded. If a scan is currently in
+// flight, its context is cancelled immediately so the new deadline takes
+// effect right away.
+func (n *nucleiExecutor) SetDeadline(d time.Duration) {
+	n.deadlineMu.Lock()
+	defer n.deadlineMu.Unlock()
+
+	if n.cancel != nil {
+		n.cancel()
+		n.cancel = nil
+	}
+	n.deadline = d
+}
+
+// withDeadline derives a scan context from ctx bounded by the executor's
+// configured deadline and records its cancel func under deadlineMu so a
+// concurrent SetDeadline call can cancel it cleanly. The returned cancel
+// must always be called once the scan completes.
+func (n *nucleiExecutor) withDeadline(ctx context.Context) (context.Context, context.CancelFunc, error) {
+	n.deadlineMu.Lock()
+	defer n.deadlineMu.Unlock()
+
+	if n.deadline < 0 {
+		return nil, nil, ErrDeadlineExceeded
+	}
+
+	var scanCtx context.Context
+	var cancel context.CancelFunc
+	if n.deadline == 0 {
+		scanCtx, cancel = context.WithCancel(ctx)
+	} else {
+		scanCtx, cancel = context.WithTimeout(ctx, n.deadline)
+	}
+	n.cancel = cancel
+	return scanCtx, cancel, nil
+}
+
+func (n *nucleiExecutor) ExecuteScan(ctx context.Context, target PostReuestsHandlerRequest) error {
+	scanCtx, cancel, err := n.withDeadline(ctx)
+	if err != nil {
+		return err
+	}
+	defer cancel()
+
 	finalTemplates := []*templates.Template{}
 	finalTemplates = append(finalTemplates, n.store.Templates()...)
 	finalTemplates = append(finalTemplates, n.store.Workflows()...)
@@ -173,7 +264,7 @@ func (n *nucleiExecutor) ExecuteScan(target PostReuestsHandlerRequest) error {
 	if err != nil {
 		return errors.Wrap(err, "could not create input provider")
 	}
-	_ = n.engine.ExecuteScanWithOpts(context.Background(), finalTemplates, inputProvider, true)
+	_ = n.engine.ExecuteScanWithOpts(scanCtx, finalTemplates, inputProvider, true)
 	return nil
 }
 
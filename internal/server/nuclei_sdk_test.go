@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNucleiExecutorWithDeadlineZeroMeansNoTimeout(t *testing.T) {
+	n := &nucleiExecutor{}
+
+	ctx, cancel, err := n.withDeadline(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); ok {
+		t.Fatal("expected no deadline on the derived context")
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("expected the derived context to be live, got %s", ctx.Err())
+	}
+}
+
+func TestNucleiExecutorWithDeadlineNegativeReturnsErrDeadlineExceeded(t *testing.T) {
+	n := &nucleiExecutor{deadline: -time.Second}
+
+	ctx, cancel, err := n.withDeadline(context.Background())
+	if err != ErrDeadlineExceeded {
+		t.Fatalf("expected ErrDeadlineExceeded, got %v", err)
+	}
+	if ctx != nil || cancel != nil {
+		t.Fatal("expected a nil context and cancel func when the deadline has already elapsed")
+	}
+}
+
+func TestNucleiExecutorWithDeadlinePositiveBoundsContext(t *testing.T) {
+	n := &nucleiExecutor{deadline: time.Hour}
+
+	ctx, cancel, err := n.withDeadline(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer cancel()
+
+	if _, ok := ctx.Deadline(); !ok {
+		t.Fatal("expected the derived context to carry a deadline")
+	}
+}
+
+func TestNucleiExecutorSetDeadlineCancelsInFlightScan(t *testing.T) {
+	n := &nucleiExecutor{}
+
+	scanCtx, cancel, err := n.withDeadline(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	defer cancel()
+
+	n.SetDeadline(time.Hour)
+
+	select {
+	case <-scanCtx.Done():
+	case <-time.After(time.Second):
+		t.Fatal("expected the in-flight scan context to be cancelled by SetDeadline")
+	}
+	if scanCtx.Err() != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", scanCtx.Err())
+	}
+}
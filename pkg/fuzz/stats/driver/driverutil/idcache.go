@@ -0,0 +1,35 @@
+// Package driverutil holds helpers shared by every pkg/fuzz/stats driver
+// implementation.
+package driverutil
+
+import "sync"
+
+// IDCache is a concurrency-safe string->int lookup cache. Drivers use it to
+// avoid round-tripping to the backing store for every site, template and
+// component lookup on the fuzzing hot path.
+type IDCache struct {
+	mu    sync.Mutex
+	items map[string]int
+}
+
+// NewIDCache creates a new, empty IDCache.
+func NewIDCache() *IDCache {
+	return &IDCache{items: make(map[string]int)}
+}
+
+// Get returns the cached id for key, if present.
+func (c *IDCache) Get(key string) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, ok := c.items[key]
+	return id, ok
+}
+
+// Set stores id under key.
+func (c *IDCache) Set(key string, id int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.items[key] = id
+}
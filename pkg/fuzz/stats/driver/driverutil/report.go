@@ -0,0 +1,109 @@
+package driverutil
+
+import (
+	_ "embed"
+	"html/template"
+	"os"
+	"sort"
+)
+
+// ReportTemplate is the HTML template shared by every StatsDatabase driver's
+// GenerateReport, executed against a ReportData.
+//
+//go:embed report.html.tmpl
+var ReportTemplate string
+
+// SeverityOrder controls the bucket display order in the fuzzing report.
+// Severities outside this list (e.g. a custom template severity) still show
+// up, sorted alphabetically after the known buckets.
+var SeverityOrder = []string{"critical", "high", "medium", "low", "info", "unknown"}
+
+// Finding is a single matched fuzzing_results row, joined with its site,
+// template and component names, ready to render into a report.
+type Finding struct {
+	Site         string
+	Component    string
+	Template     string
+	Payload      string
+	StatusCode   int
+	Severity     string
+	RequestHash  string
+	ResponseHash string
+}
+
+// SeverityBucket groups the findings sharing a severity for the report.
+type SeverityBucket struct {
+	Severity string
+	Findings []Finding
+}
+
+// ReportData is the root object passed to the fuzzing report template.
+type ReportData struct {
+	// BadVulns is the total of matched critical+high severity findings,
+	// mirroring the BadVulns-style triage summary of a vulnerability scan.
+	BadVulns       int
+	TemplateCounts map[string]int
+	SiteCounts     map[string]int
+	Buckets        []SeverityBucket
+	// Blobs maps a raw request/response hash to its content, so the report
+	// can deep-link a finding row to the request/response that produced it.
+	Blobs map[string]string
+}
+
+// BuildReportData groups findings by severity and computes the summary
+// counts shown at the top of the report.
+func BuildReportData(findings []Finding) ReportData {
+	data := ReportData{
+		TemplateCounts: make(map[string]int),
+		SiteCounts:     make(map[string]int),
+	}
+
+	bucketed := make(map[string][]Finding)
+	for _, finding := range findings {
+		severity := finding.Severity
+		if severity == "" {
+			severity = "unknown"
+		}
+		bucketed[severity] = append(bucketed[severity], finding)
+		data.TemplateCounts[finding.Template]++
+		data.SiteCounts[finding.Site]++
+		if severity == "critical" || severity == "high" {
+			data.BadVulns++
+		}
+	}
+
+	for _, severity := range SeverityOrder {
+		if findings, ok := bucketed[severity]; ok {
+			data.Buckets = append(data.Buckets, SeverityBucket{Severity: severity, Findings: findings})
+			delete(bucketed, severity)
+		}
+	}
+
+	var remaining []string
+	for severity := range bucketed {
+		remaining = append(remaining, severity)
+	}
+	sort.Strings(remaining)
+	for _, severity := range remaining {
+		data.Buckets = append(data.Buckets, SeverityBucket{Severity: severity, Findings: bucketed[severity]})
+	}
+
+	return data
+}
+
+// RenderReport executes tmplText against data and writes the result to
+// outputPath.
+func RenderReport(outputPath, tmplText string, data ReportData) error {
+	tmpl, err := template.New("report").Parse(tmplText)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return tmpl.Execute(file, data)
+}
@@ -0,0 +1,538 @@
+// Package sqlite implements the "sqlite" stats.StatsDatabase driver. It is
+// the default backend, selected when a DSN has no scheme, and keeps the
+// historical behavior of writing a single `<scan-name>.stats.db` file.
+package sqlite
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+	"github.com/pkg/errors"
+	"github.com/projectdiscovery/gologger"
+
+	"github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats"
+	"github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats/driver/driverutil"
+)
+
+// Defaults used when the caller leaves stats.DriverOptions unset.
+const (
+	defaultBatchSize     = 200
+	defaultFlushInterval = 500 * time.Millisecond
+)
+
+func init() {
+	stats.Register("sqlite", New)
+}
+
+//go:embed schema.sql
+var schemaCreateStatement string
+
+type statsDatabase struct {
+	db       *sql.DB
+	filename string
+
+	siteIDCache      *driverutil.IDCache
+	templateIDCache  *driverutil.IDCache
+	componentIDCache *driverutil.IDCache
+
+	// eventCh buffers fuzzing results for the background flush loop.
+	// InsertRecord/InsertMatchedRecord enqueue onto it and only fall back to
+	// a synchronous write when it is full, so the hot fuzzing path no longer
+	// pays for a per-event transaction.
+	eventCh       chan queuedEvent
+	batchSize     int
+	flushInterval time.Duration
+	flushDone     sync.WaitGroup
+
+	// closeMu guards closed/eventCh against the race between Close and a
+	// concurrent enqueue: Close takes the write side so it only closes
+	// eventCh once every in-flight enqueue (holding the read side) has
+	// observed closed or finished its send, and every enqueue after that
+	// sees closed and takes the sync fallback instead of touching a closed
+	// channel.
+	closeMu sync.RWMutex
+	closed  bool
+}
+
+// queuedEvent is a fuzzing result waiting to be flushed by flushLoop.
+type queuedEvent struct {
+	event   stats.FuzzingEvent
+	matched bool
+}
+
+// New creates a StatsDatabase backed by a local SQLite file. dsn is either a
+// bare scan name (historical behavior, written to "<dsn>.stats.db") or a
+// "sqlite://<path>" DSN naming the database file directly. opts.BatchSize and
+// opts.FlushInterval tune the background batch writer; zero values fall back
+// to defaultBatchSize/defaultFlushInterval.
+func New(dsn string, opts stats.DriverOptions) (stats.StatsDatabase, error) {
+	filename := filenameFromDSN(dsn)
+
+	connectionString := fmt.Sprintf("./%s?_journal_mode=WAL&_synchronous=NORMAL", filename)
+	db, err := sql.Open("sqlite3", connectionString)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not open database")
+	}
+
+	if _, err := db.Exec(schemaCreateStatement); err != nil {
+		return nil, errors.Wrap(err, "could not create schema")
+	}
+
+	batchSize := opts.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSize
+	}
+	flushInterval := opts.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = defaultFlushInterval
+	}
+
+	s := &statsDatabase{
+		filename:         filename,
+		db:               db,
+		siteIDCache:      driverutil.NewIDCache(),
+		templateIDCache:  driverutil.NewIDCache(),
+		componentIDCache: driverutil.NewIDCache(),
+		eventCh:          make(chan queuedEvent, batchSize),
+		batchSize:        batchSize,
+		flushInterval:    flushInterval,
+	}
+
+	s.flushDone.Add(1)
+	go s.flushLoop()
+
+	return s, nil
+}
+
+func filenameFromDSN(dsn string) string {
+	if strings.HasPrefix(dsn, "sqlite://") {
+		return strings.TrimPrefix(dsn, "sqlite://")
+	}
+	return fmt.Sprintf("%s.stats.db", dsn)
+}
+
+func (s *statsDatabase) Close() {
+	// Taking the write lock waits out every enqueue currently holding the
+	// read lock, so once closed is set and eventCh is closed here, no
+	// enqueue call can still be racing a send on it.
+	s.closeMu.Lock()
+	s.closed = true
+	close(s.eventCh)
+	s.closeMu.Unlock()
+
+	// flushLoop drains whatever is left and flushes it one last time before
+	// we remove the WAL/SHM files.
+	s.flushDone.Wait()
+
+	// Close the database and remove the WAL/SHM files left beside it
+	_ = s.db.Close()
+	os.Remove(fmt.Sprintf("%s-wal", s.filename))
+	os.Remove(fmt.Sprintf("%s-shm", s.filename))
+}
+
+func (s *statsDatabase) InsertRecord(event stats.FuzzingEvent) error {
+	return s.enqueue(event, false)
+}
+
+func (s *statsDatabase) InsertMatchedRecord(event stats.FuzzingEvent) error {
+	return s.enqueue(event, true)
+}
+
+// enqueue hands event to the background flush loop. It falls back to
+// writing event synchronously when eventCh is full (the scan is producing
+// events faster than they can be flushed) or once Close has been called, so
+// neither a burst nor a shutdown racing a fuzzing worker drops a result or
+// sends on a closed channel.
+func (s *statsDatabase) enqueue(event stats.FuzzingEvent, matched bool) error {
+	s.closeMu.RLock()
+	defer s.closeMu.RUnlock()
+
+	if s.closed {
+		return s.insertResult(event, matched)
+	}
+
+	select {
+	case s.eventCh <- queuedEvent{event: event, matched: matched}:
+		return nil
+	default:
+		return s.insertResult(event, matched)
+	}
+}
+
+// flushLoop drains eventCh in the background, batching up to batchSize
+// events or flushInterval of waiting, whichever comes first, into a single
+// transaction. It returns once eventCh is closed and the final partial
+// batch has been flushed.
+func (s *statsDatabase) flushLoop() {
+	defer s.flushDone.Done()
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]queuedEvent, 0, s.batchSize)
+	for {
+		select {
+		case qe, ok := <-s.eventCh:
+			if !ok {
+				s.flushBatch(batch)
+				return
+			}
+			batch = append(batch, qe)
+			if len(batch) >= s.batchSize {
+				s.flushBatch(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			s.flushBatch(batch)
+			batch = batch[:0]
+		}
+	}
+}
+
+// flushBatch writes batch in a single transaction using prepared statements
+// for the per-event inserts. Failures are logged rather than returned: by
+// the time a background flush runs, the caller that produced the event has
+// long since moved on.
+func (s *statsDatabase) flushBatch(batch []queuedEvent) {
+	if len(batch) == 0 {
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		gologger.Warning().Msgf("stats: could not begin batch flush transaction: %s", err)
+		return
+	}
+
+	blobStmt, err := tx.Prepare(`INSERT OR IGNORE INTO raw_blobs (hash, content) VALUES (?, ?)`)
+	if err != nil {
+		gologger.Warning().Msgf("stats: could not prepare raw_blobs statement: %s", err)
+		_ = tx.Rollback()
+		return
+	}
+	defer blobStmt.Close()
+
+	resultStmt, err := tx.Prepare(`
+        INSERT INTO fuzzing_results (component_id, template_id, payload_sent, status_code_received, matched, severity, raw_request_hash, raw_response_hash)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `)
+	if err != nil {
+		gologger.Warning().Msgf("stats: could not prepare fuzzing_results statement: %s", err)
+		_ = tx.Rollback()
+		return
+	}
+	defer resultStmt.Close()
+
+	for _, qe := range batch {
+		if err := s.insertResultTx(tx, blobStmt, resultStmt, qe.event, qe.matched); err != nil {
+			gologger.Warning().Msgf("stats: could not insert fuzzing result: %s", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		gologger.Warning().Msgf("stats: could not commit batch flush transaction: %s", err)
+	}
+}
+
+// insertResultTx writes a single event as part of a caller-managed batch
+// transaction, reusing blobStmt/resultStmt instead of preparing them again
+// per event.
+func (s *statsDatabase) insertResultTx(tx *sql.Tx, blobStmt, resultStmt *sql.Stmt, event stats.FuzzingEvent, matched bool) error {
+	siteID, err := s.getSiteID(tx, event.SiteName())
+	if err != nil {
+		return errors.Wrap(err, "could not get site_id")
+	}
+
+	templateID, err := s.getTemplateID(tx, event.TemplateID)
+	if err != nil {
+		return errors.Wrap(err, "could not get template_id")
+	}
+
+	componentID, err := s.getComponentID(tx, siteID, event.ComponentType, event.ComponentName)
+	if err != nil {
+		return errors.Wrap(err, "could not get component_id")
+	}
+
+	requestHash, responseHash, err := s.putRawBlobPrepared(blobStmt, event.RawRequest, event.RawResponse)
+	if err != nil {
+		return errors.Wrap(err, "could not store raw request/response blob")
+	}
+
+	if _, err := resultStmt.Exec(componentID, templateID, event.PayloadSent, event.StatusCode, matched, event.Severity, requestHash, responseHash); err != nil {
+		return errors.Wrap(err, "could not insert fuzzing result")
+	}
+	return nil
+}
+
+// putRawBlobPrepared is putRawBlob's prepared-statement counterpart, used
+// by the batch flush path.
+func (s *statsDatabase) putRawBlobPrepared(blobStmt *sql.Stmt, rawRequest, rawResponse string) (requestHash, responseHash string, err error) {
+	requestHash = hashBlob(rawRequest)
+	if _, err = blobStmt.Exec(requestHash, rawRequest); err != nil {
+		return "", "", err
+	}
+
+	responseHash = hashBlob(rawResponse)
+	if _, err = blobStmt.Exec(responseHash, rawResponse); err != nil {
+		return "", "", err
+	}
+
+	return requestHash, responseHash, nil
+}
+
+func (s *statsDatabase) insertResult(event stats.FuzzingEvent, matched bool) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	siteID, err := s.getSiteID(tx, event.SiteName())
+	if err != nil {
+		return errors.Wrap(err, "could not get site_id")
+	}
+
+	templateID, err := s.getTemplateID(tx, event.TemplateID)
+	if err != nil {
+		return errors.Wrap(err, "could not get template_id")
+	}
+
+	componentID, err := s.getComponentID(tx, siteID, event.ComponentType, event.ComponentName)
+	if err != nil {
+		return errors.Wrap(err, "could not get component_id")
+	}
+
+	requestHash, responseHash, err := s.putRawBlob(tx, event.RawRequest, event.RawResponse)
+	if err != nil {
+		return errors.Wrap(err, "could not store raw request/response blob")
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO fuzzing_results (component_id, template_id, payload_sent, status_code_received, matched, severity, raw_request_hash, raw_response_hash)
+        VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    `, componentID, templateID, event.PayloadSent, event.StatusCode, matched, event.Severity, requestHash, responseHash)
+	if err != nil {
+		return errors.Wrap(err, "could not insert fuzzing result")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+// putRawBlob stores the raw request and response content-addressed by their
+// sha256 hash, so repeated payloads across events are only stored once, and
+// returns the hashes to reference from fuzzing_results.
+func (s *statsDatabase) putRawBlob(tx *sql.Tx, rawRequest, rawResponse string) (requestHash, responseHash string, err error) {
+	requestHash = hashBlob(rawRequest)
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO raw_blobs (hash, content) VALUES (?, ?)`, requestHash, rawRequest); err != nil {
+		return "", "", err
+	}
+
+	responseHash = hashBlob(rawResponse)
+	if _, err = tx.Exec(`INSERT OR IGNORE INTO raw_blobs (hash, content) VALUES (?, ?)`, responseHash, rawResponse); err != nil {
+		return "", "", err
+	}
+
+	return requestHash, responseHash, nil
+}
+
+func hashBlob(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *statsDatabase) InsertComponent(event stats.ComponentEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	siteID, err := s.getSiteID(tx, event.SiteName())
+	if err != nil {
+		return errors.Wrap(err, "could not get site_id")
+	}
+
+	if _, err = s.getComponentID(tx, siteID, event.ComponentType, event.ComponentName); err != nil {
+		return errors.Wrap(err, "could not get component_id")
+	}
+
+	return tx.Commit()
+}
+
+func (s *statsDatabase) InsertError(event stats.ErrorEvent) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return errors.Wrap(err, "could not begin transaction")
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	siteID, err := s.getSiteID(tx, event.SiteName())
+	if err != nil {
+		return errors.Wrap(err, "could not get site_id")
+	}
+
+	templateID, err := s.getTemplateID(tx, event.TemplateID)
+	if err != nil {
+		return errors.Wrap(err, "could not get template_id")
+	}
+
+	_, err = tx.Exec(`
+        INSERT INTO fuzzing_errors (site_id, template_id, error)
+        VALUES (?, ?, ?)
+    `, siteID, templateID, event.Error)
+	if err != nil {
+		return errors.Wrap(err, "could not insert fuzzing error")
+	}
+
+	if err := tx.Commit(); err != nil {
+		return errors.Wrap(err, "could not commit transaction")
+	}
+	return nil
+}
+
+func (s *statsDatabase) GetStatistics() stats.SimpleStatsResponse {
+	var resp stats.SimpleStatsResponse
+
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM sites`).Scan(&resp.Sites)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM templates`).Scan(&resp.Templates)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM components`).Scan(&resp.Components)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM fuzzing_results`).Scan(&resp.Requests)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM fuzzing_results WHERE matched = 1`).Scan(&resp.Matches)
+	_ = s.db.QueryRow(`SELECT COUNT(*) FROM fuzzing_errors`).Scan(&resp.Errors)
+
+	return resp
+}
+
+func (s *statsDatabase) GenerateReport(outputPath string) error {
+	rows, err := s.db.Query(`
+        SELECT si.site_name, t.template_name, c.component_name, r.payload_sent, r.status_code_received,
+               r.severity, r.raw_request_hash, r.raw_response_hash
+        FROM fuzzing_results r
+        JOIN components c ON c.component_id = r.component_id
+        JOIN sites si ON si.site_id = c.site_id
+        JOIN templates t ON t.template_id = r.template_id
+        WHERE r.matched = 1
+        ORDER BY r.result_id
+    `)
+	if err != nil {
+		return errors.Wrap(err, "could not query fuzzing results")
+	}
+	defer rows.Close()
+
+	var findings []driverutil.Finding
+	for rows.Next() {
+		var finding driverutil.Finding
+		if err := rows.Scan(&finding.Site, &finding.Template, &finding.Component, &finding.Payload, &finding.StatusCode,
+			&finding.Severity, &finding.RequestHash, &finding.ResponseHash); err != nil {
+			return errors.Wrap(err, "could not scan fuzzing result")
+		}
+		findings = append(findings, finding)
+	}
+
+	data := driverutil.BuildReportData(findings)
+	data.Blobs, err = s.loadRawBlobs()
+	if err != nil {
+		return errors.Wrap(err, "could not load raw blobs")
+	}
+
+	return driverutil.RenderReport(outputPath, driverutil.ReportTemplate, data)
+}
+
+func (s *statsDatabase) loadRawBlobs() (map[string]string, error) {
+	rows, err := s.db.Query(`SELECT hash, content FROM raw_blobs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	blobs := make(map[string]string)
+	for rows.Next() {
+		var hash, content string
+		if err := rows.Scan(&hash, &content); err != nil {
+			return nil, err
+		}
+		blobs[hash] = content
+	}
+	return blobs, nil
+}
+
+func (s *statsDatabase) getSiteID(tx *sql.Tx, siteName string) (int, error) {
+	if id, ok := s.siteIDCache.Get(siteName); ok {
+		return id, nil
+	}
+
+	var siteID int
+	err := tx.QueryRow(`
+        INSERT INTO sites (site_name) VALUES (?)
+        ON CONFLICT(site_name) DO UPDATE SET site_name = excluded.site_name
+        RETURNING site_id
+    `, siteName).Scan(&siteID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.siteIDCache.Set(siteName, siteID)
+	return siteID, nil
+}
+
+func (s *statsDatabase) getTemplateID(tx *sql.Tx, templateName string) (int, error) {
+	if id, ok := s.templateIDCache.Get(templateName); ok {
+		return id, nil
+	}
+
+	var templateID int
+	err := tx.QueryRow(`
+        INSERT INTO templates (template_name) VALUES (?)
+        ON CONFLICT(template_name) DO UPDATE SET template_name = excluded.template_name
+        RETURNING template_id
+    `, templateName).Scan(&templateID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.templateIDCache.Set(templateName, templateID)
+	return templateID, nil
+}
+
+func (s *statsDatabase) getComponentID(tx *sql.Tx, siteID int, componentType, componentName string) (int, error) {
+	key := fmt.Sprintf("%d:%s:%s", siteID, componentType, componentName)
+	if id, ok := s.componentIDCache.Get(key); ok {
+		return id, nil
+	}
+
+	var componentID int
+	err := tx.QueryRow(`
+        INSERT INTO components (site_id, component_type, component_name) VALUES (?, ?, ?)
+        ON CONFLICT(site_id, component_type, component_name) DO UPDATE SET component_name = excluded.component_name
+        RETURNING component_id
+    `, siteID, componentType, componentName).Scan(&componentID)
+	if err != nil {
+		return 0, err
+	}
+
+	s.componentIDCache.Set(key, componentID)
+	return componentID, nil
+}
@@ -0,0 +1,73 @@
+package sqlite
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/projectdiscovery/nuclei/v3/pkg/fuzz/stats"
+)
+
+// newBenchDB creates a statsDatabase backed by a scratch file removed when
+// the benchmark completes, so the BenchmarkInsertResult_* benchmarks can run
+// repeatedly without polluting the working directory.
+func newBenchDB(b *testing.B, opts stats.DriverOptions) *statsDatabase {
+	b.Helper()
+
+	name := strings.ReplaceAll(b.Name(), "/", "_")
+	db, err := New(fmt.Sprintf("bench-%s-%d", name, os.Getpid()), opts)
+	if err != nil {
+		b.Fatalf("could not create bench stats db: %s", err)
+	}
+
+	sd := db.(*statsDatabase)
+	b.Cleanup(func() {
+		sd.Close()
+		_ = os.Remove(sd.filename)
+	})
+	return sd
+}
+
+func benchEvent(i int) stats.FuzzingEvent {
+	return stats.FuzzingEvent{
+		URL:           "https://example.com",
+		ComponentType: "param",
+		ComponentName: "q",
+		TemplateID:    "template-1",
+		PayloadSent:   fmt.Sprintf("payload-%d", i),
+		StatusCode:    200,
+		RawRequest:    "GET /?q=" + fmt.Sprint(i) + " HTTP/1.1",
+		RawResponse:   "HTTP/1.1 200 OK",
+		Severity:      "info",
+	}
+}
+
+// BenchmarkInsertResult_Sync exercises the historical path that InsertRecord
+// used before batching: a fresh transaction per event. It's still reachable
+// today as enqueue's overflow fallback.
+func BenchmarkInsertResult_Sync(b *testing.B) {
+	sd := newBenchDB(b, stats.DriverOptions{})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sd.insertResult(benchEvent(i), false); err != nil {
+			b.Fatalf("insertResult: %s", err)
+		}
+	}
+}
+
+// BenchmarkInsertResult_Async exercises the batched path: InsertRecord
+// enqueues onto eventCh and flushLoop commits events in batches in the
+// background.
+func BenchmarkInsertResult_Async(b *testing.B) {
+	sd := newBenchDB(b, stats.DriverOptions{BatchSize: 200, FlushInterval: 50 * time.Millisecond})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := sd.InsertRecord(benchEvent(i)); err != nil {
+			b.Fatalf("InsertRecord: %s", err)
+		}
+	}
+}
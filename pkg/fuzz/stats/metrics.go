@@ -0,0 +1,137 @@
+package stats
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metrics holds the live Prometheus counters/gauges for a Tracker. It is
+// updated directly from RecordResultEvent/RecordComponentEvent/
+// RecordErrorEvent rather than derived from the StatsDatabase, so scraping
+// never puts load on the backing store.
+type metrics struct {
+	registry *prometheus.Registry
+
+	requestsTotal *prometheus.CounterVec
+	matchesTotal  *prometheus.CounterVec
+	errorsTotal   *prometheus.CounterVec
+
+	sitesTracked      prometheus.Gauge
+	templatesTracked  prometheus.Gauge
+	componentsTracked prometheus.Gauge
+
+	mu         sync.Mutex
+	sites      map[string]struct{}
+	templates  map[string]struct{}
+	components map[string]struct{}
+}
+
+func newMetrics() *metrics {
+	registry := prometheus.NewRegistry()
+
+	m := &metrics{
+		registry: registry,
+		requestsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nuclei_fuzzing_requests_total",
+			Help: "Total number of fuzzing requests sent.",
+		}, []string{"site", "template", "component_type", "status_code"}),
+		matchesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nuclei_fuzzing_matches_total",
+			Help: "Total number of fuzzing matches found.",
+		}, []string{"site", "template", "severity"}),
+		errorsTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "nuclei_fuzzing_errors_total",
+			Help: "Total number of errors encountered while fuzzing.",
+		}, []string{"template", "site"}),
+		sitesTracked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nuclei_fuzzing_sites_tracked",
+			Help: "Number of unique sites currently tracked.",
+		}),
+		templatesTracked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nuclei_fuzzing_templates_tracked",
+			Help: "Number of unique templates currently tracked.",
+		}),
+		componentsTracked: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "nuclei_fuzzing_components_tracked",
+			Help: "Number of unique components currently tracked.",
+		}),
+		sites:      make(map[string]struct{}),
+		templates:  make(map[string]struct{}),
+		components: make(map[string]struct{}),
+	}
+
+	registry.MustRegister(
+		m.requestsTotal, m.matchesTotal, m.errorsTotal,
+		m.sitesTracked, m.templatesTracked, m.componentsTracked,
+	)
+	return m
+}
+
+func (m *metrics) trackSite(site string) {
+	if site == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.sites[site]; !ok {
+		m.sites[site] = struct{}{}
+		m.sitesTracked.Set(float64(len(m.sites)))
+	}
+}
+
+func (m *metrics) trackTemplate(template string) {
+	if template == "" {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.templates[template]; !ok {
+		m.templates[template] = struct{}{}
+		m.templatesTracked.Set(float64(len(m.templates)))
+	}
+}
+
+func (m *metrics) trackComponent(site, componentType, componentName string) {
+	if componentName == "" {
+		return
+	}
+	key := site + ":" + componentType + ":" + componentName
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.components[key]; !ok {
+		m.components[key] = struct{}{}
+		m.componentsTracked.Set(float64(len(m.components)))
+	}
+}
+
+func (m *metrics) recordResult(event FuzzingEvent, site string) {
+	m.trackSite(site)
+	m.trackTemplate(event.TemplateID)
+	m.trackComponent(site, event.ComponentType, event.ComponentName)
+
+	m.requestsTotal.WithLabelValues(site, event.TemplateID, event.ComponentType, strconv.Itoa(event.StatusCode)).Inc()
+	if event.Matched {
+		m.matchesTotal.WithLabelValues(site, event.TemplateID, event.Severity).Inc()
+	}
+}
+
+func (m *metrics) recordComponent(event ComponentEvent, site string) {
+	m.trackSite(site)
+	m.trackComponent(site, event.ComponentType, event.ComponentName)
+}
+
+func (m *metrics) recordError(event ErrorEvent, site string) {
+	m.trackSite(site)
+	m.trackTemplate(event.TemplateID)
+	m.errorsTotal.WithLabelValues(event.TemplateID, site).Inc()
+}
+
+// MetricsHandler returns an http.Handler serving t's live fuzzing counters
+// and gauges in Prometheus/OpenMetrics exposition format.
+func MetricsHandler(t *Tracker) http.Handler {
+	return promhttp.HandlerFor(t.metrics.registry, promhttp.HandlerOpts{})
+}
@@ -5,24 +5,62 @@ package stats
 import (
 	"fmt"
 	"net/url"
+	"time"
 
 	"github.com/pkg/errors"
 )
 
 // Tracker is a stats tracker module for fuzzing server
 type Tracker struct {
-	database *simpleStats
+	database StatsDatabase
+	metrics  *metrics
 }
 
-// NewTracker creates a new tracker instance
-func NewTracker() (*Tracker, error) {
-	db, err := NewSimpleStats()
+// SimpleStatsResponse is a point-in-time snapshot of the stats tracked for
+// the current fuzzing session.
+type SimpleStatsResponse struct {
+	Sites      int `json:"sites"`
+	Templates  int `json:"templates"`
+	Components int `json:"components"`
+	Requests   int `json:"requests"`
+	Matches    int `json:"matches"`
+	Errors     int `json:"errors"`
+}
+
+// TrackerOption configures tunables passed down to the selected
+// StatsDatabase driver. See DriverOptions for the knobs a driver may use.
+type TrackerOption func(*DriverOptions)
+
+// WithBatchSize sets the max number of events an async driver buffers
+// before flushing them in a single transaction.
+func WithBatchSize(n int) TrackerOption {
+	return func(o *DriverOptions) { o.BatchSize = n }
+}
+
+// WithFlushInterval sets the max time an event waits in an async driver's
+// buffer before a flush happens regardless of batch size.
+func WithFlushInterval(d time.Duration) TrackerOption {
+	return func(o *DriverOptions) { o.FlushInterval = d }
+}
+
+// NewTracker creates a new tracker instance backed by the driver selected by
+// dsn. dsn is either a bare scan name (selects the sqlite driver for
+// backwards compatibility) or a driver DSN such as "postgres://..." or
+// "clickhouse://...".
+func NewTracker(dsn string, opts ...TrackerOption) (*Tracker, error) {
+	var driverOpts DriverOptions
+	for _, opt := range opts {
+		opt(&driverOpts)
+	}
+
+	db, err := newStatsDatabase(dsn, driverOpts)
 	if err != nil {
 		return nil, errors.Wrap(err, "could not create new tracker")
 	}
 
 	tracker := &Tracker{
 		database: db,
+		metrics:  newMetrics(),
 	}
 	return tracker, nil
 }
@@ -31,6 +69,11 @@ func (t *Tracker) GetStats() SimpleStatsResponse {
 	return t.database.GetStatistics()
 }
 
+// GenerateReport writes an HTML report of the fuzzing session to outputPath.
+func (t *Tracker) GenerateReport(outputPath string) error {
+	return t.database.GenerateReport(outputPath)
+}
+
 // Close closes the tracker
 func (t *Tracker) Close() {
 	t.database.Close()
@@ -48,26 +91,36 @@ type FuzzingEvent struct {
 	RawRequest    string
 	RawResponse   string
 	Severity      string
+}
 
-	siteName string
+// SiteName returns the host:port combo that the event belongs to.
+func (e FuzzingEvent) SiteName() string {
+	return getCorrectSiteName(e.URL)
 }
 
 func (t *Tracker) RecordResultEvent(event FuzzingEvent) {
-	event.siteName = getCorrectSiteName(event.URL)
-	t.database.InsertMatchedRecord(event)
+	t.metrics.recordResult(event, event.SiteName())
+	if event.Matched {
+		_ = t.database.InsertMatchedRecord(event)
+	} else {
+		_ = t.database.InsertRecord(event)
+	}
 }
 
 type ComponentEvent struct {
 	URL           string
 	ComponentType string
 	ComponentName string
+}
 
-	siteName string
+// SiteName returns the host:port combo that the event belongs to.
+func (e ComponentEvent) SiteName() string {
+	return getCorrectSiteName(e.URL)
 }
 
 func (t *Tracker) RecordComponentEvent(event ComponentEvent) {
-	event.siteName = getCorrectSiteName(event.URL)
-	t.database.InsertComponent(event)
+	t.metrics.recordComponent(event, event.SiteName())
+	_ = t.database.InsertComponent(event)
 }
 
 type ErrorEvent struct {
@@ -76,8 +129,14 @@ type ErrorEvent struct {
 	Error      string
 }
 
+// SiteName returns the host:port combo that the event belongs to.
+func (e ErrorEvent) SiteName() string {
+	return getCorrectSiteName(e.URL)
+}
+
 func (t *Tracker) RecordErrorEvent(event ErrorEvent) {
-	t.database.InsertError(event)
+	t.metrics.recordError(event, event.SiteName())
+	_ = t.database.InsertError(event)
 }
 
 func getCorrectSiteName(originalURL string) string {
@@ -96,4 +155,4 @@ func getCorrectSiteName(originalURL string) string {
 		}
 	}
 	return siteName
-}
\ No newline at end of file
+}